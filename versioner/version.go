@@ -1,7 +1,10 @@
 package version
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"sync"
 
 	gover "github.com/hashicorp/go-version"
 )
@@ -35,9 +38,13 @@ func IsDeprecated(a Versioner) bool { return a.Deprecated() }
 // but we still want to do a version check on ALL functions anyway
 // This response simply responds to that
 type GenericVersioner struct {
-	min        string
-	max        string
-	deprecated bool
+	min             string
+	max             string
+	deprecated      bool
+	deprecatedSince string
+	removedIn       string
+	replacedBy      string
+	prereleaseMode  PrereleaseMode
 }
 
 // MinVersion returns the minimum version required
@@ -59,6 +66,37 @@ func (g *GenericVersioner) MaxVersion() Version {
 // Deprecated returns if a thing is deprecated
 func (g *GenericVersioner) Deprecated() bool { return g.deprecated }
 
+// DeprecatedSince returns the version in which this thing was deprecated, or
+// the zero Version if none was set.
+func (g *GenericVersioner) DeprecatedSince() Version {
+	if g.deprecatedSince == "" {
+		return Version{}
+	}
+	return MustParse(g.deprecatedSince)
+}
+
+// RemovedIn returns the version in which this thing will be (or was) removed,
+// or the zero Version if none was set.
+func (g *GenericVersioner) RemovedIn() Version {
+	if g.removedIn == "" {
+		return Version{}
+	}
+	return MustParse(g.removedIn)
+}
+
+// ReplacedBy returns the identifier of the thing that should be used in place
+// of this deprecated thing, or "" if none was set.
+func (g *GenericVersioner) ReplacedBy() string { return g.replacedBy }
+
+// PrereleaseMode returns the mode g was configured with via
+// SetPrereleaseMode, or StrictSemver if it was never set.
+func (g *GenericVersioner) PrereleaseMode() PrereleaseMode { return g.prereleaseMode }
+
+// SetPrereleaseMode configures how g treats prerelease and pseudo versions in
+// range checks performed by CheckSupportedVersion. CheckSupportedVersionOpts
+// ignores it and uses the mode in its CheckOptions instead.
+func (g *GenericVersioner) SetPrereleaseMode(mode PrereleaseMode) { g.prereleaseMode = mode }
+
 // NewGenericVersioner returns a versioner with the specified constraints
 func NewGenericVersioner(minimum, maximum string, isDeprecated bool) *GenericVersioner {
 	thing := &GenericVersioner{
@@ -69,6 +107,21 @@ func NewGenericVersioner(minimum, maximum string, isDeprecated bool) *GenericVer
 	return thing
 }
 
+// NewDeprecatedGenericVersioner returns a GenericVersioner like
+// NewGenericVersioner, additionally recording the version in which it was
+// deprecated, the version in which it will be removed, and a suggested
+// replacement identifier.
+func NewDeprecatedGenericVersioner(minimum, maximum, deprecatedSince, removedIn, replacedBy string) *GenericVersioner {
+	return &GenericVersioner{
+		min:             minimum,
+		max:             maximum,
+		deprecated:      true,
+		deprecatedSince: deprecatedSince,
+		removedIn:       removedIn,
+		replacedBy:      replacedBy,
+	}
+}
+
 // MustParse is a panicing version of NewVersion
 func MustParse(v string) Version {
 	ver, err := gover.NewVersion(v)
@@ -78,8 +131,64 @@ func MustParse(v string) Version {
 	return Version{ver}
 }
 
-// CheckSupportedVersion checks a versioner against a provided version string
+// CheckSupportedVersion checks a versioner against a provided version string.
+// If v also implements ConstraintsVersioner, the check is delegated to
+// CheckSupportedConstraints instead of the inclusive [min,max] comparison,
+// since a constraints expression can express things (excluded point releases,
+// compound conditions) the min/max window cannot. If the requested version is
+// otherwise supported but v implements DeprecationInfo and ver is at or past
+// its DeprecatedSince, a *DeprecationError is returned instead of nil. If v
+// implements PrereleasePolicy, its PrereleaseMode governs prerelease
+// matching; types that don't opt in get StrictSemver.
 func CheckSupportedVersion(v Versioner, ver string) error {
+	mode := StrictSemver
+	if pp, ok := v.(PrereleasePolicy); ok {
+		mode = pp.PrereleaseMode()
+	}
+	return CheckSupportedVersionOpts(v, ver, CheckOptions{PrereleaseMode: mode})
+}
+
+// PrereleaseMode controls how a prerelease or pseudo/date-stamped version is
+// matched against a Versioner's [min,max] range.
+type PrereleaseMode int
+
+const (
+	// StrictSemver compares a prerelease against min/max using ordinary
+	// semver precedence. This is the default.
+	StrictSemver PrereleaseMode = iota
+	// AllowPrereleaseInRange matches a prerelease whenever its release core
+	// (major.minor.patch) falls within [min,max].
+	AllowPrereleaseInRange
+	// NamedPrereleaseOnly applies Cargo/npm semantics: a prerelease only
+	// satisfies the range if min or max itself names a prerelease on the
+	// same release core.
+	NamedPrereleaseOnly
+)
+
+// PrereleasePolicy is implemented opportunistically by a Versioner that wants
+// to control its PrereleaseMode. Types that don't implement it are treated
+// as StrictSemver.
+type PrereleasePolicy interface {
+	PrereleaseMode() PrereleaseMode
+}
+
+// CheckOptions carries per-call overrides for CheckSupportedVersionOpts.
+type CheckOptions struct {
+	// PrereleaseMode overrides whatever v reports via PrereleasePolicy for
+	// this call only.
+	PrereleaseMode PrereleaseMode
+}
+
+// CheckSupportedVersionOpts is CheckSupportedVersion with an explicit
+// PrereleaseMode, overriding whatever v reports via PrereleasePolicy.
+func CheckSupportedVersionOpts(v Versioner, ver string, opts CheckOptions) error {
+	if cv, ok := v.(ConstraintsVersioner); ok {
+		if err := CheckSupportedConstraints(cv, ver); err != nil {
+			return err
+		}
+		return checkDeprecation(v, ver)
+	}
+
 	min := GetMinVersionFor(v)
 	max := GetMaxVersionFor(v)
 
@@ -87,12 +196,391 @@ func CheckSupportedVersion(v Versioner, ver string) error {
 	if err != nil {
 		return err
 	}
-	if myver.Equal(max.Version) || myver.Equal(min.Version) {
+	if inSupportedRange(min.Version, max.Version, myver, opts.PrereleaseMode) {
+		return checkDeprecation(v, ver)
+	}
+	return fmt.Errorf("Requested version (%s) does not meet the requirements for this type (min: %s, max: %s)",
+		myver.String(), min.String(), max.String())
+}
+
+// inSupportedRange reports whether myver falls within the inclusive
+// [min,max] window, applying mode to decide whether a prerelease counts.
+func inSupportedRange(min, max, myver *gover.Version, mode PrereleaseMode) bool {
+	basicMatch := myver.Equal(max) || myver.Equal(min) ||
+		(myver.GreaterThan(min) && myver.LessThan(max))
+
+	if myver.Prerelease() == "" {
+		return basicMatch
+	}
+
+	switch mode {
+	case AllowPrereleaseInRange:
+		core, minCore, maxCore := versionCore(myver), versionCore(min), versionCore(max)
+		return core.Equal(maxCore) || core.Equal(minCore) ||
+			(core.GreaterThan(minCore) && core.LessThan(maxCore))
+	case NamedPrereleaseOnly:
+		return basicMatch && (prereleaseNamed(min, myver) || prereleaseNamed(max, myver))
+	default: // StrictSemver
+		return basicMatch
+	}
+}
+
+// prereleaseNamed reports whether bound itself names a prerelease on the
+// same release core as myver.
+func prereleaseNamed(bound, myver *gover.Version) bool {
+	return bound.Prerelease() != "" && versionCore(bound).Equal(versionCore(myver))
+}
+
+// versionCore returns v truncated to its major.minor.patch core, dropping
+// any prerelease or build metadata.
+func versionCore(v *gover.Version) *gover.Version {
+	segs := v.Segments()
+	core, err := gover.NewVersion(fmt.Sprintf("%d.%d.%d", segs[0], segs[1], segs[2]))
+	if err != nil {
+		return v
+	}
+	return core
+}
+
+// pseudoVersionRe matches the prerelease component of a Go-style
+// pseudo-version: a 14-digit timestamp and a 12-hex-digit commit hash,
+// either bare ("20200101000000-abcdef123456"), preceded by "0."
+// ("0.20200101000000-abcdef123456"), or preceded by a base prerelease tag
+// and "0." ("pre.0.20200101000000-abcdef123456").
+var pseudoVersionRe = regexp.MustCompile(`^(?:(?:[0-9A-Za-z-]+\.)?0\.)?\d{14}-[0-9a-f]{12}$`)
+
+// IsPrerelease reports whether v has a non-empty prerelease component, e.g.
+// "1.2.0-rc1". It returns false for the zero Version.
+func IsPrerelease(v Version) bool {
+	if v.Version == nil {
+		return false
+	}
+	return v.Prerelease() != ""
+}
+
+// IsPseudo reports whether v looks like a Go-style pseudo-version, e.g.
+// "v0.0.0-20200101000000-abcdef123456". It returns false for the zero
+// Version.
+func IsPseudo(v Version) bool {
+	if v.Version == nil {
+		return false
+	}
+	return pseudoVersionRe.MatchString(v.Prerelease())
+}
+
+// DeprecationInfo is implemented opportunistically by a Versioner that can
+// report richer deprecation metadata than the boolean Deprecated(): the
+// version in which it was deprecated, the version in which it will be
+// removed, and a suggested replacement.
+type DeprecationInfo interface {
+	DeprecatedSince() Version
+	RemovedIn() Version
+	ReplacedBy() string
+}
+
+// DeprecationError is returned by CheckSupportedVersion when the requested
+// version is otherwise supported but falls on or after the type's
+// DeprecatedSince version.
+type DeprecationError struct {
+	Version         string
+	DeprecatedSince Version
+	RemovedIn       Version
+	ReplacedBy      string
+}
+
+// Error implements the error interface.
+func (e *DeprecationError) Error() string {
+	msg := fmt.Sprintf("version %s is deprecated as of %s", e.Version, e.DeprecatedSince.String())
+	if e.ReplacedBy != "" {
+		msg += fmt.Sprintf(", use %s instead", e.ReplacedBy)
+	}
+	if e.RemovedIn.Version != nil {
+		msg += fmt.Sprintf(" (will be removed in %s)", e.RemovedIn.String())
+	}
+	return msg
+}
+
+// checkDeprecation returns a *DeprecationError if v implements
+// DeprecationInfo and reports a DeprecatedSince at or before ver. It returns
+// nil for types that don't opt in to DeprecationInfo or haven't set a
+// DeprecatedSince.
+func checkDeprecation(v Versioner, ver string) error {
+	di, ok := v.(DeprecationInfo)
+	if !ok {
 		return nil
 	}
-	if myver.GreaterThan(min.Version) && myver.LessThan(max.Version) {
+	since := di.DeprecatedSince()
+	if since.Version == nil {
 		return nil
 	}
-	return fmt.Errorf("Requested version (%s) does not meet the requirements for this type (min: %s, max: %s)",
-		myver.String(), min.String(), max.String())
+	myver, err := gover.NewVersion(ver)
+	if err != nil {
+		return nil
+	}
+	if myver.Equal(since.Version) || myver.GreaterThan(since.Version) {
+		return &DeprecationError{
+			Version:         myver.String(),
+			DeprecatedSince: since,
+			RemovedIn:       di.RemovedIn(),
+			ReplacedBy:      di.ReplacedBy(),
+		}
+	}
+	return nil
+}
+
+// IsRemoved reports whether ver is at or beyond the version in which v,
+// if it implements DeprecationInfo, was removed. It returns false for types
+// that don't report a removal version.
+func IsRemoved(v Versioner, ver string) bool {
+	di, ok := v.(DeprecationInfo)
+	if !ok {
+		return false
+	}
+	removedIn := di.RemovedIn()
+	if removedIn.Version == nil {
+		return false
+	}
+	myver, err := gover.NewVersion(ver)
+	if err != nil {
+		return false
+	}
+	return myver.Equal(removedIn.Version) || myver.GreaterThan(removedIn.Version)
+}
+
+// ConstraintsVersioner is implemented by types that express support using a
+// go-version Constraints expression rather than (or in addition to) a simple
+// min/max window.
+type ConstraintsVersioner interface {
+	Constraints() gover.Constraints
+}
+
+// ConstraintVersioner is a Versioner that enforces support using a
+// gover.Constraints expression (e.g. ">=1.2, <2.0, !=1.5.0") instead of the
+// inclusive [min,max] window used by GenericVersioner. It still carries
+// min/max/deprecated so it satisfies Versioner on its own.
+type ConstraintVersioner struct {
+	GenericVersioner
+	constraints gover.Constraints
+}
+
+// NewConstraintVersioner returns a ConstraintVersioner enforcing expr, e.g.
+// ">=1.2, <2.0, !=1.5.0". It panics if expr cannot be parsed, mirroring
+// MustParse.
+func NewConstraintVersioner(minimum, maximum string, isDeprecated bool, expr string) *ConstraintVersioner {
+	c, err := gover.NewConstraint(expr)
+	if err != nil {
+		panic("cannot parse constraints")
+	}
+	return &ConstraintVersioner{
+		GenericVersioner: *NewGenericVersioner(minimum, maximum, isDeprecated),
+		constraints:      c,
+	}
+}
+
+// Constraints returns the constraint expression this versioner enforces.
+func (c *ConstraintVersioner) Constraints() gover.Constraints { return c.constraints }
+
+// CheckSupportedConstraints checks ver against v's Constraints() expression.
+func CheckSupportedConstraints(v ConstraintsVersioner, ver string) error {
+	myver, err := gover.NewVersion(ver)
+	if err != nil {
+		return err
+	}
+	if !v.Constraints().Check(myver) {
+		return fmt.Errorf("Requested version (%s) does not satisfy constraints (%s)",
+			myver.String(), v.Constraints().String())
+	}
+	return nil
+}
+
+var langVersionRe = regexp.MustCompile(`^v?([0-9]+)\.([0-9]+)`)
+
+// LangVersion truncates v to its major.minor "language version", analogous
+// to Go's version.Lang, dropping any patch number and prerelease/build
+// metadata. "1.20.5" and "1.20rc2" both yield "1.20". It returns the zero
+// Version if v does not start with a major.minor pair.
+func LangVersion(v string) Version {
+	m := langVersionRe.FindStringSubmatch(v)
+	if m == nil {
+		return Version{}
+	}
+	return MustParse(m[1] + "." + m[2])
+}
+
+// LangVersioner is a Versioner whose MinVersion/MaxVersion are expressed as
+// major.minor language versions (e.g. "1.20") and should be checked against
+// the lang-truncated form of the requested version via
+// CheckSupportedLangVersion, so that an API declaring support for "1.20"
+// accepts "1.20.1", "1.20.rc1", and so on without listing every patch.
+type LangVersioner struct {
+	GenericVersioner
+}
+
+// NewLangVersioner returns a LangVersioner requiring at least minimum and at
+// most maximum, both expressed as major.minor language versions.
+func NewLangVersioner(minimum, maximum string, isDeprecated bool) *LangVersioner {
+	return &LangVersioner{GenericVersioner: *NewGenericVersioner(minimum, maximum, isDeprecated)}
+}
+
+// CheckSupportedLangVersion checks ver against v after truncating ver to its
+// major.minor language version, e.g. "1.20.1" and "1.20rc2" are both checked
+// as "1.20".
+func CheckSupportedLangVersion(v Versioner, ver string) error {
+	lang := LangVersion(ver)
+	if lang.Version == nil {
+		return fmt.Errorf("cannot parse %q as a language version", ver)
+	}
+	return CheckSupportedVersion(v, lang.String())
+}
+
+// Registry maintains a set of Versioner implementations keyed by an API
+// method/path/type name, along with an optional per-key override map of
+// effective versions, mirroring the FileVersions technique used in go/types.
+// A server registers its endpoints once via Register and then checks
+// requests against them uniformly via Check, pinning specific keys to a
+// negotiated version via SetOverride. A Registry is safe for concurrent use.
+type Registry struct {
+	mu         sync.RWMutex
+	versioners map[string]Versioner
+	overrides  map[string]string
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		versioners: make(map[string]Versioner),
+		overrides:  make(map[string]string),
+	}
+}
+
+// Register associates key with v, replacing any existing entry for key.
+func (r *Registry) Register(key string, v Versioner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.versioners[key] = v
+}
+
+// Check checks ver against the Versioner registered under key.
+func (r *Registry) Check(key, ver string) error {
+	r.mu.RLock()
+	v, ok := r.versioners[key]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no versioner registered for %q", key)
+	}
+	return CheckSupportedVersion(v, ver)
+}
+
+// SetOverride pins key's effective version to ver, as returned by
+// Effective(key), independent of what Check validates.
+func (r *Registry) SetOverride(key, ver string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides[key] = ver
+}
+
+// Effective returns the effective version for key: its override if one was
+// set via SetOverride, otherwise the max version supported by its registered
+// Versioner. It returns "" if key has neither an override nor a registered
+// Versioner.
+func (r *Registry) Effective(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if ov, ok := r.overrides[key]; ok {
+		return ov
+	}
+	v, ok := r.versioners[key]
+	if !ok {
+		return ""
+	}
+	return v.MaxVersion().String()
+}
+
+// DeprecatedKeys returns the keys of all registered Versioners reporting
+// Deprecated() == true, in no particular order, for building deprecation
+// reports.
+func (r *Registry) DeprecatedKeys() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]string, 0)
+	for key, v := range r.versioners {
+		if v.Deprecated() {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// cmpVersion orders a and b, treating the zero Version as an unbounded
+// maximum, the way cmd/go/internal/modload/mvs.go's cmpVersion does.
+func cmpVersion(a, b Version) int {
+	aUnbounded := a.Version == nil
+	bUnbounded := b.Version == nil
+	switch {
+	case aUnbounded && bUnbounded:
+		return 0
+	case aUnbounded:
+		return 1
+	case bUnbounded:
+		return -1
+	default:
+		return a.Compare(b.Version)
+	}
+}
+
+// Highest returns the highest Version in vs, treating the zero Version as an
+// unbounded maximum. It returns the zero Version if vs is empty.
+func Highest(vs []Version) Version {
+	if len(vs) == 0 {
+		return Version{}
+	}
+	highest := vs[0]
+	for _, v := range vs[1:] {
+		if cmpVersion(v, highest) > 0 {
+			highest = v
+		}
+	}
+	return highest
+}
+
+// Lowest returns the lowest Version in vs, treating the zero Version as an
+// unbounded maximum, so it is only returned when vs contains nothing else.
+// It returns the zero Version if vs is empty.
+func Lowest(vs []Version) Version {
+	if len(vs) == 0 {
+		return Version{}
+	}
+	lowest := vs[0]
+	for _, v := range vs[1:] {
+		if cmpVersion(v, lowest) < 0 {
+			lowest = v
+		}
+	}
+	return lowest
+}
+
+// Select picks the Versioner among candidates that supports requested,
+// preferring the highest MinVersion on ties - the MVS idea from Go's module
+// loader adapted to dispatching versioned API handlers. A candidate that
+// only supports requested because it is deprecated still counts.
+func Select(candidates []Versioner, requested string) (Versioner, error) {
+	var best Versioner
+	var bestMin Version
+	for _, c := range candidates {
+		if err := CheckSupportedVersion(c, requested); err != nil {
+			var depErr *DeprecationError
+			if !errors.As(err, &depErr) {
+				continue
+			}
+		}
+		min := c.MinVersion()
+		if best == nil || cmpVersion(min, bestMin) > 0 {
+			best = c
+			bestMin = min
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no candidate supports requested version (%s)", requested)
+	}
+	return best, nil
 }
@@ -1,6 +1,7 @@
 package version
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -54,3 +55,190 @@ func TestMustParse(t *testing.T) {
 	x := func() { _ = MustParse("foobar") }
 	require.Panics(t, x)
 }
+
+func TestConstraintVersioner(t *testing.T) {
+	x := NewConstraintVersioner("1.0", "2.0", false, ">=1.2, <2.0, !=1.5.0")
+	require.NotNil(t, x)
+
+	require.NoError(t, CheckSupportedConstraints(x, "1.2"))
+	require.NoError(t, CheckSupportedConstraints(x, "1.9"))
+	require.Error(t, CheckSupportedConstraints(x, "1.5.0"))
+	require.Error(t, CheckSupportedConstraints(x, "1.1"))
+	require.Error(t, CheckSupportedConstraints(x, "2.0"))
+	require.Error(t, CheckSupportedConstraints(x, "foobar"))
+}
+
+func TestConstraintVersionerPanicsOnBadExpr(t *testing.T) {
+	x := func() { _ = NewConstraintVersioner("1.0", "2.0", false, "not a constraint") }
+	require.Panics(t, x)
+}
+
+func TestCheckSupportedVersionDelegatesToConstraints(t *testing.T) {
+	x := NewConstraintVersioner("1.0", "2.0", false, ">=1.2, <2.0, !=1.5.0")
+
+	require.NoError(t, CheckSupportedVersion(x, "1.2"))
+	require.Error(t, CheckSupportedVersion(x, "1.5.0"))
+}
+
+func TestLangVersion(t *testing.T) {
+	require.Equal(t, "1.20.0", LangVersion("1.20").String())
+	require.Equal(t, "1.20.0", LangVersion("1.20.0").String())
+	require.Equal(t, "1.20.0", LangVersion("1.20.5").String())
+	require.Equal(t, "1.20.0", LangVersion("1.20rc2").String())
+	require.Nil(t, LangVersion("foobar").Version)
+}
+
+func TestDeprecationMetadata(t *testing.T) {
+	x := NewDeprecatedGenericVersioner("1.0", "3.0", "2.0", "3.0", "v2/widget")
+	require.True(t, IsDeprecated(x))
+	require.Equal(t, "2.0.0", x.DeprecatedSince().String())
+	require.Equal(t, "3.0.0", x.RemovedIn().String())
+	require.Equal(t, "v2/widget", x.ReplacedBy())
+
+	err := CheckSupportedVersion(x, "1.5")
+	require.NoError(t, err)
+
+	err = CheckSupportedVersion(x, "2.5")
+	require.Error(t, err)
+	var depErr *DeprecationError
+	require.ErrorAs(t, err, &depErr)
+	require.Equal(t, "2.0.0", depErr.DeprecatedSince.String())
+	require.Equal(t, "3.0.0", depErr.RemovedIn.String())
+	require.Equal(t, "v2/widget", depErr.ReplacedBy)
+}
+
+func TestIsRemoved(t *testing.T) {
+	x := NewDeprecatedGenericVersioner("1.0", "3.0", "2.0", "3.0", "v2/widget")
+
+	require.False(t, IsRemoved(x, "2.5"))
+	require.True(t, IsRemoved(x, "3.0"))
+
+	plain := NewGenericVersioner("1.0.1", "1.9", false)
+	require.False(t, IsRemoved(plain, "5.0"))
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Register("widget.Create", NewGenericVersioner("1.0", "2.0", false))
+	r.Register("widget.Delete", NewDeprecatedGenericVersioner("1.0", "2.0", "1.5", "2.0", "widget.Remove"))
+
+	require.NoError(t, r.Check("widget.Create", "1.5"))
+	require.Error(t, r.Check("widget.Create", "9.9"))
+	require.Error(t, r.Check("missing.Key", "1.0"))
+
+	require.Equal(t, "2.0.0", r.Effective("widget.Create"))
+	r.SetOverride("widget.Create", "1.5")
+	require.Equal(t, "1.5", r.Effective("widget.Create"))
+	require.Equal(t, "", r.Effective("missing.Key"))
+
+	require.Equal(t, []string{"widget.Delete"}, r.DeprecatedKeys())
+}
+
+func TestRegistryConcurrentAccess(t *testing.T) {
+	r := NewRegistry()
+	r.Register("widget.Create", NewGenericVersioner("1.0", "2.0", false))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = r.Check("widget.Create", "1.5")
+			_ = r.Effective("widget.Create")
+			_ = r.DeprecatedKeys()
+		}()
+		go func() {
+			defer wg.Done()
+			r.SetOverride("widget.Create", "1.5")
+		}()
+	}
+	wg.Wait()
+}
+
+func TestHighestAndLowest(t *testing.T) {
+	vs := []Version{MustParse("1.0"), MustParse("2.0"), Version{}, MustParse("0.5")}
+
+	require.Nil(t, Highest(vs).Version)
+	require.Equal(t, "0.5.0", Lowest(vs).String())
+
+	require.Nil(t, Highest(nil).Version)
+	require.Nil(t, Lowest(nil).Version)
+}
+
+func TestSelect(t *testing.T) {
+	v1 := NewGenericVersioner("1.0", "1.9", false)
+	v2 := NewGenericVersioner("2.0", "2.9", false)
+	v2Deprecated := NewDeprecatedGenericVersioner("1.5", "1.9", "1.6", "2.0", "widget.CreateV2")
+
+	picked, err := Select([]Versioner{v1, v2}, "2.5")
+	require.NoError(t, err)
+	require.Equal(t, v2, picked)
+
+	picked, err = Select([]Versioner{v1, v2}, "1.2")
+	require.NoError(t, err)
+	require.Equal(t, v1, picked)
+
+	_, err = Select([]Versioner{v1, v2}, "9.9")
+	require.Error(t, err)
+
+	picked, err = Select([]Versioner{v1, v2Deprecated}, "1.7")
+	require.NoError(t, err)
+	require.Equal(t, v2Deprecated, picked)
+}
+
+func TestCheckSupportedLangVersion(t *testing.T) {
+	x := NewLangVersioner("1.20", "1.22", false)
+
+	require.NoError(t, CheckSupportedLangVersion(x, "1.20"))
+	require.NoError(t, CheckSupportedLangVersion(x, "1.20.0"))
+	require.NoError(t, CheckSupportedLangVersion(x, "1.20.5"))
+	require.NoError(t, CheckSupportedLangVersion(x, "1.20rc2"))
+	require.Error(t, CheckSupportedLangVersion(x, "1.19.9"))
+	require.Error(t, CheckSupportedLangVersion(x, "foobar"))
+}
+
+func TestIsPrerelease(t *testing.T) {
+	require.True(t, IsPrerelease(MustParse("1.2.0-rc1")))
+	require.False(t, IsPrerelease(MustParse("1.2.0")))
+	require.False(t, IsPrerelease(Version{}))
+}
+
+func TestIsPseudo(t *testing.T) {
+	require.True(t, IsPseudo(MustParse("0.0.0-20200101000000-abcdef123456")))
+	require.True(t, IsPseudo(MustParse("1.2.1-0.20200101000000-abcdef123456")))
+	require.False(t, IsPseudo(MustParse("1.2.0-rc1")))
+	require.False(t, IsPseudo(MustParse("1.2.0")))
+	require.False(t, IsPseudo(Version{}))
+}
+
+func TestCheckSupportedVersionOptsStrictSemver(t *testing.T) {
+	x := NewGenericVersioner("1.0", "2.0", false)
+
+	require.NoError(t, CheckSupportedVersionOpts(x, "2.0.0-rc1", CheckOptions{PrereleaseMode: StrictSemver}))
+	require.Error(t, CheckSupportedVersionOpts(x, "2.1.0-rc1", CheckOptions{PrereleaseMode: StrictSemver}))
+}
+
+func TestCheckSupportedVersionOptsAllowPrereleaseInRange(t *testing.T) {
+	x := NewGenericVersioner("1.0", "2.0", false)
+
+	require.NoError(t, CheckSupportedVersionOpts(x, "2.0.0-rc1", CheckOptions{PrereleaseMode: AllowPrereleaseInRange}))
+	require.Error(t, CheckSupportedVersionOpts(x, "2.1.0-rc1", CheckOptions{PrereleaseMode: AllowPrereleaseInRange}))
+}
+
+func TestCheckSupportedVersionOptsNamedPrereleaseOnly(t *testing.T) {
+	x := NewGenericVersioner("1.0", "2.0", false)
+
+	require.Error(t, CheckSupportedVersionOpts(x, "2.0.0-rc1", CheckOptions{PrereleaseMode: NamedPrereleaseOnly}))
+
+	named := NewGenericVersioner("1.0", "2.0.0-rc1", false)
+	require.NoError(t, CheckSupportedVersionOpts(named, "2.0.0-rc1", CheckOptions{PrereleaseMode: NamedPrereleaseOnly}))
+}
+
+func TestGenericVersionerPrereleaseMode(t *testing.T) {
+	x := NewGenericVersioner("1.0", "2.0", false)
+	require.Equal(t, StrictSemver, x.PrereleaseMode())
+
+	x.SetPrereleaseMode(NamedPrereleaseOnly)
+	require.Equal(t, NamedPrereleaseOnly, x.PrereleaseMode())
+	require.Error(t, CheckSupportedVersion(x, "2.0.0-rc1"))
+}